@@ -0,0 +1,218 @@
+package gzseek
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// bgzfExtraLen is the length of a BGZF "BC" extra subfield's payload: a
+// single little-endian uint16 holding BSIZE-1.
+const bgzfExtraLen = 2
+
+// bgzfEOF is the 28-byte empty BGZF block that terminates a well-formed
+// BGZF stream (see the SAM/BAM specification, section 4.1.2).
+var bgzfEOF = []byte{
+	0x1f, 0x8b, 0x08, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0x06, 0x00,
+	0x42, 0x43, 0x02, 0x00, 0x1b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00,
+}
+
+// bgzfBlockSize reports the total on-disk length of the gzip member whose
+// FEXTRA field is extra, if it is a well-formed BGZF "BC" subfield, and
+// whether one was found.
+func bgzfBlockSize(extra []byte) (size int, ok bool) {
+	for len(extra) >= 4 {
+		si1, si2, slen := extra[0], extra[1], int(extra[2])|int(extra[3])<<8
+		extra = extra[4:]
+		if len(extra) < slen {
+			return 0, false
+		}
+		if si1 == 'B' && si2 == 'C' && slen == bgzfExtraLen {
+			bsize := int(extra[0]) | int(extra[1])<<8
+			return bsize + 1, true
+		}
+		extra = extra[slen:]
+	}
+	return 0, false
+}
+
+// BGZFBlock describes one independently-decodable BGZF member.
+type BGZFBlock struct {
+	CompressedOffset   int64
+	UncompressedOffset int64
+	CompressedSize     int64
+	UncompressedSize   int64
+}
+
+// IsBGZF reports whether z's source was recognized as BGZF (Blocked GZip
+// Format, as used by BAM/VCF/tabix) when it was opened: every member's
+// gzip header carries a FEXTRA "BC" subfield giving the member's total
+// on-disk size.
+func (z *Reader) IsBGZF() bool {
+	return z.bgzfBlockSize > 0
+}
+
+// BuildBGZFIndex walks a BGZF stream's member boundaries, recording the
+// compressed and uncompressed offset of each block. Unlike BuildIndex,
+// this requires no flate state snapshotting: BGZF members are capped at
+// 64 KiB uncompressed and independently decodable, so the block table is
+// derived entirely from each member's header (BSIZE) and trailer (ISIZE),
+// without decompressing any member body.
+//
+// z's source must implement io.ReaderAt. IsBGZF must be true.
+func (z *Reader) BuildBGZFIndex() ([]BGZFBlock, error) {
+	if !z.IsBGZF() {
+		return nil, errors.New("gzseek: source is not BGZF")
+	}
+	ra, ok := z.src.(io.ReaderAt)
+	if !ok {
+		return nil, ErrNotReaderAt
+	}
+
+	var blocks []BGZFBlock
+	var compOff, uncompOff int64
+	hdr := make([]byte, 18) // 10-byte gzip header + 2-byte XLEN, plus room for the 6-byte BC subfield
+	for {
+		n, err := ra.ReadAt(hdr[:12], compOff)
+		if err == io.EOF && n == 0 {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if n < 12 {
+			break
+		}
+		xlen := int(hdr[10]) | int(hdr[11])<<8
+		extra := make([]byte, xlen)
+		if _, err := ra.ReadAt(extra, compOff+12); err != nil {
+			return nil, err
+		}
+		size, ok := bgzfBlockSize(extra)
+		if !ok {
+			return nil, errors.New("gzseek: malformed BGZF member (missing BC subfield)")
+		}
+		trailer := make([]byte, 8)
+		if _, err := ra.ReadAt(trailer, compOff+int64(size)-8); err != nil {
+			return nil, err
+		}
+		isize := int64(binary.LittleEndian.Uint32(trailer[4:8]))
+
+		// A BGZF stream ends with a standard, fixed 28-byte empty block
+		// (ISIZE == 0) as an EOF marker (SAM/BAM spec, section 4.1.2). It
+		// isn't a real data block, so samtools/tabix-style block tables
+		// exclude it; stop here rather than recording a bogus final entry.
+		if isize == 0 {
+			break
+		}
+
+		blocks = append(blocks, BGZFBlock{
+			CompressedOffset:   compOff,
+			UncompressedOffset: uncompOff,
+			CompressedSize:     int64(size),
+			UncompressedSize:   isize,
+		})
+		compOff += int64(size)
+		uncompOff += isize
+	}
+	z.bgzfBlocks = blocks
+	return blocks, nil
+}
+
+// SeekVirtual repositions z using a BGZF virtual file offset, as used by
+// the BAM/tabix/.gzi ecosystem: the high 48 bits of voffset are the
+// compressed offset of the member containing the target position, and the
+// low 16 bits are the uncompressed offset within that (at most 64 KiB)
+// member.
+//
+// IsBGZF must be true; BuildBGZFIndex need not have been called, since a
+// virtual offset is independently decodable without an index.
+func (z *Reader) SeekVirtual(voffset int64) error {
+	if !z.IsBGZF() {
+		return errors.New("gzseek: source is not BGZF")
+	}
+	if z.readerAt == nil {
+		return ErrNotReaderAt
+	}
+	compOff := voffset >> 16
+	withinBlock := voffset & 0xffff
+
+	// compOff is the on-disk offset of the BGZF member itself (per the
+	// SAM spec's virtual offset definition), not its deflate bitstream:
+	// unlike a Checkpoint from BuildIndex, it still has a gzip header in
+	// front of it, so it must be parsed via readHeader rather than fed
+	// straight to restoreAt. Each BGZF member is its own independent
+	// gzip stream, so no dictionary is needed either way.
+	sr := io.NewSectionReader(z.readerAt, compOff, 1<<63-1-compOff)
+	cr := &countingReader{r: sr, base: compOff}
+	bgzfBlockSize, bgzfBlocks := z.bgzfBlockSize, z.bgzfBlocks
+	*z = Reader{
+		cr:            cr,
+		r:             makeReader(cr),
+		multistream:   true,
+		src:           z.src,
+		readerAt:      z.readerAt,
+		bgzfBlockSize: bgzfBlockSize,
+		bgzfBlocks:    bgzfBlocks,
+	}
+	z.compressedOffset = compOff
+	if err := z.readHeader(); err != nil {
+		return err
+	}
+
+	if withinBlock > 0 {
+		if _, err := io.CopyN(io.Discard, readerFunc(z.Read), withinBlock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteGZI writes z's BGZF block table in the standard .gzi format used
+// by bgzip/tabix: a little-endian uint64 entry count, followed by that
+// many (compressedOffset, uncompressedOffset) uint64 pairs, one per block
+// boundary after the first.
+func WriteGZI(w io.Writer, blocks []BGZFBlock) error {
+	if len(blocks) == 0 {
+		return binary.Write(w, binary.LittleEndian, uint64(0))
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint64(len(blocks)-1)); err != nil {
+		return err
+	}
+	for _, b := range blocks[1:] {
+		if err := binary.Write(w, binary.LittleEndian, uint64(b.CompressedOffset)); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, uint64(b.UncompressedOffset)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadGZI reads a .gzi file as written by WriteGZI, reconstructing block
+// boundaries. The implicit first block at (0, 0) is included in the
+// result.
+func ReadGZI(r io.Reader) ([]BGZFBlock, error) {
+	var count uint64
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return nil, err
+	}
+	blocks := make([]BGZFBlock, 0, count+1)
+	blocks = append(blocks, BGZFBlock{})
+	for i := uint64(0); i < count; i++ {
+		var compOff, uncompOff uint64
+		if err := binary.Read(r, binary.LittleEndian, &compOff); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.LittleEndian, &uncompOff); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, BGZFBlock{
+			CompressedOffset:   int64(compOff),
+			UncompressedOffset: int64(uncompOff),
+		})
+	}
+	return blocks, nil
+}
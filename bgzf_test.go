@@ -0,0 +1,111 @@
+package gzseek
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// bgzfExtraPlaceholder is the FEXTRA payload for a BGZF member before its
+// BSIZE field is patched in: subfield "BC", length 2, BSIZE placeholder.
+var bgzfExtraPlaceholder = []byte{'B', 'C', 2, 0, 0, 0}
+
+// writeBGZFMember gzip-compresses payload as a single BGZF member and
+// returns its bytes, with BSIZE correctly patched in.
+func writeBGZFMember(t *testing.T, payload []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz, err := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+	if err != nil {
+		t.Fatalf("NewWriterLevel: %v", err)
+	}
+	gz.Extra = bgzfExtraPlaceholder
+	if _, err := gz.Write(payload); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	b := buf.Bytes()
+	bsize := uint16(len(b) - 1)
+	binary.LittleEndian.PutUint16(b[16:18], bsize)
+	return b
+}
+
+func makeBGZFFixture(t *testing.T, payloads [][]byte) []byte {
+	t.Helper()
+	var out []byte
+	for _, p := range payloads {
+		out = append(out, writeBGZFMember(t, p)...)
+	}
+	out = append(out, bgzfEOF...)
+	return out
+}
+
+func TestBGZFDetectionAndIndex(t *testing.T) {
+	payloads := [][]byte{
+		bytes.Repeat([]byte("alpha"), 1000),
+		bytes.Repeat([]byte("beta"), 2000),
+		bytes.Repeat([]byte("gamma"), 500),
+	}
+	raw := makeBGZFFixture(t, payloads)
+	src := readerAtBytes{bytes.NewReader(raw)}
+
+	z, err := NewReader(src)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	if !z.IsBGZF() {
+		t.Fatal("expected IsBGZF to be true")
+	}
+
+	blocks, err := z.BuildBGZFIndex()
+	if err != nil {
+		t.Fatalf("BuildBGZFIndex: %v", err)
+	}
+	if len(blocks) != len(payloads) {
+		t.Fatalf("got %d blocks, want %d", len(blocks), len(payloads))
+	}
+	var uncompOff int64
+	for i, b := range blocks {
+		if b.UncompressedOffset != uncompOff {
+			t.Errorf("block %d: got uncompressed offset %d, want %d", i, b.UncompressedOffset, uncompOff)
+		}
+		if b.UncompressedSize != int64(len(payloads[i])) {
+			t.Errorf("block %d: got uncompressed size %d, want %d", i, b.UncompressedSize, len(payloads[i]))
+		}
+		uncompOff += b.UncompressedSize
+	}
+
+	var gzi bytes.Buffer
+	if err := WriteGZI(&gzi, blocks); err != nil {
+		t.Fatalf("WriteGZI: %v", err)
+	}
+	got, err := ReadGZI(&gzi)
+	if err != nil {
+		t.Fatalf("ReadGZI: %v", err)
+	}
+	if len(got) != len(blocks) {
+		t.Fatalf("round-tripped %d blocks, want %d", len(got), len(blocks))
+	}
+	for i := range blocks {
+		if got[i].CompressedOffset != blocks[i].CompressedOffset || got[i].UncompressedOffset != blocks[i].UncompressedOffset {
+			t.Errorf("block %d: round-trip mismatch: got %+v, want offsets (%d, %d)", i, got[i], blocks[i].CompressedOffset, blocks[i].UncompressedOffset)
+		}
+	}
+
+	// SeekVirtual into the second block, partway through.
+	voffset := blocks[1].CompressedOffset<<16 | 10
+	if err := z.SeekVirtual(voffset); err != nil {
+		t.Fatalf("SeekVirtual: %v", err)
+	}
+	got2, err := io.ReadAll(io.LimitReader(z, int64(len(payloads[1])-10)))
+	if err != nil {
+		t.Fatalf("ReadAll after SeekVirtual: %v", err)
+	}
+	if !bytes.Equal(got2, payloads[1][10:]) {
+		t.Fatalf("SeekVirtual produced mismatched data")
+	}
+}
@@ -0,0 +1,144 @@
+// Command gzran builds, inspects, and reads from gzseek index files.
+//
+// Usage:
+//
+//	gzran build [-span bytes] file.gz file.gzx
+//	gzran print file.gzx
+//	gzran extract -off N -len N file.gz file.gzx
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/timpalpant/gzran"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "build":
+		err = runBuild(os.Args[2:])
+	case "print":
+		err = runPrint(os.Args[2:])
+	case "extract":
+		err = runExtract(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gzran:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: gzran build|print|extract ...")
+}
+
+func runBuild(args []string) error {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	span := fs.Int64("span", 1<<20, "uncompressed bytes between checkpoints")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: gzran build [-span bytes] file.gz file.gzx")
+	}
+	gzPath, idxPath := fs.Arg(0), fs.Arg(1)
+
+	f, err := os.Open(gzPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	z, err := gzseek.NewReader(f)
+	if err != nil {
+		return err
+	}
+	idx, err := z.BuildIndex(context.Background(), gzseek.FixedUncompressedSpan(*span), func(uncompressed, compressed int64) {
+		fmt.Fprintf(os.Stderr, "\rindexing: %d bytes (%d compressed)", uncompressed, compressed)
+	})
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(idxPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := idx.WriteTo(out); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func runPrint(args []string) error {
+	fs := flag.NewFlagSet("print", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: gzran print file.gzx")
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	idx, err := gzseek.LoadIndex(f)
+	if err != nil {
+		return err
+	}
+	for i, c := range idx.Checkpoints() {
+		fmt.Printf("%d: compressed=%d uncompressed=%d member=%d window=%dB\n",
+			i, c.CompressedOffset, c.UncompressedOffset, c.MemberIndex, len(c.Window))
+	}
+	return nil
+}
+
+func runExtract(args []string) error {
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+	off := fs.Int64("off", 0, "uncompressed offset to start reading from")
+	n := fs.Int64("len", 0, "number of uncompressed bytes to read")
+	fs.Parse(args)
+	if fs.NArg() != 2 || *n <= 0 {
+		return fmt.Errorf("usage: gzran extract -off N -len N file.gz file.gzx")
+	}
+	gzPath, idxPath := fs.Arg(0), fs.Arg(1)
+
+	idxFile, err := os.Open(idxPath)
+	if err != nil {
+		return err
+	}
+	idx, err := gzseek.LoadIndex(idxFile)
+	idxFile.Close()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(gzPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	z, err := gzseek.NewReader(f)
+	if err != nil {
+		return err
+	}
+	z.SetIndex(idx)
+	if _, err := z.Seek(*off, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.CopyN(os.Stdout, z, *n)
+	return err
+}
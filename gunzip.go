@@ -0,0 +1,346 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gzseek implements reading of gzip-format compressed files, as
+// specified in RFC 1952, with added support for random access to the
+// decompressed stream via a checkpoint-based seek index.
+//
+// It is derived from the standard library's compress/gzip package; see
+// index.go and seek.go for the additions that make seeking possible.
+package gzseek
+
+import (
+	"bufio"
+	"compress/flate"
+	"errors"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+const (
+	gzipID1     = 0x1f
+	gzipID2     = 0x8b
+	gzipDeflate = 8
+	flagText    = 1 << 0
+	flagHdrCrc  = 1 << 1
+	flagExtra   = 1 << 2
+	flagName    = 1 << 3
+	flagComment = 1 << 4
+)
+
+var (
+	// ErrChecksum is returned when reading GZIP data that has an invalid
+	// checksum.
+	ErrChecksum = errors.New("gzseek: invalid checksum")
+	// ErrHeader is returned when reading GZIP data that has an invalid
+	// header.
+	ErrHeader = errors.New("gzseek: invalid header")
+)
+
+// The gzip file stores a header giving metadata about the compressed file.
+// That header is exposed as the fields of the Reader and Writer structs.
+type Header struct {
+	Comment string    // comment
+	Extra   []byte    // "extra data"
+	ModTime time.Time // modification time
+	Name    string    // file name
+	OS      byte      // operating system type
+}
+
+// A Reader is an io.Reader that can be read to retrieve uncompressed data
+// from a gzip-format compressed file, with support for random access via
+// Seek once an index has been built (see index.go and seek.go).
+type Reader struct {
+	Header
+	r            flate.Reader
+	src          io.Reader
+	readerAt     io.ReaderAt
+	cr           *countingReader
+	decompressor io.ReadCloser
+	digest       uint32
+	size         uint32
+	buf          [512]byte
+	err          error
+	multistream  bool
+
+	// compressedOffset and uncompressedOffset track byte positions in the
+	// compressed source and decompressed output, respectively, across the
+	// lifetime of the Reader. They are used by BuildIndex and Seek.
+	compressedOffset   int64
+	uncompressedOffset int64
+
+	index *Index
+
+	// bgzfBlockSize is the total on-disk size of the first member, as
+	// declared by its FEXTRA "BC" subfield, or 0 if the stream was not
+	// recognized as BGZF. See bgzf.go.
+	bgzfBlockSize int
+	bgzfBlocks    []BGZFBlock
+
+	// members records MemberInfo for every member encountered so far. See
+	// members.go.
+	members []MemberInfo
+}
+
+// NewReader creates a new Reader reading the given reader. If r does not
+// also implement io.ByteReader, the decompressor may read more data than
+// necessary from r.
+//
+// It is the caller's responsibility to call Close on the Reader when done.
+//
+// The Reader.Header fields will be valid in the Reader returned.
+func NewReader(r io.Reader) (*Reader, error) {
+	z := new(Reader)
+	z.src = r
+	if ra, ok := r.(io.ReaderAt); ok {
+		z.readerAt = ra
+	}
+	z.cr = &countingReader{r: r}
+	z.r = makeReader(z.cr)
+	z.multistream = true
+	if err := z.readHeader(); err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+// Reset discards the Reader z's state and makes it equivalent to the
+// result of its original state from NewReader, but reading from r instead.
+// This permits reusing a Reader rather than allocating a new one.
+func (z *Reader) Reset(r io.Reader) error {
+	cr := &countingReader{r: r}
+	*z = Reader{
+		cr:          cr,
+		r:           makeReader(cr),
+		multistream: true,
+	}
+	z.src = r
+	if ra, ok := r.(io.ReaderAt); ok {
+		z.readerAt = ra
+	}
+	return z.readHeader()
+}
+
+// Multistream controls whether the reader supports multistream files.
+//
+// If enabled (the default), the Reader expects the input to be a sequence
+// of individually gzipped data streams, each with its own header and
+// trailer, ending at EOF. The effect is that the concatenation of a
+// sequence of gzipped files is treated as equivalent to the gzip of the
+// concatenation of the sequence. This is standard behavior for gzip readers.
+//
+// Calling Multistream(false) disables this behavior; disabling the
+// behavior can be useful when reading file formats that distinguish
+// individual gzip data streams or mix gzip data streams with other data
+// streams.
+func (z *Reader) Multistream(ok bool) {
+	z.multistream = ok
+}
+
+// makeReader turns r into a flate.Reader if it isn't already one.
+func makeReader(r io.Reader) flate.Reader {
+	if rr, ok := r.(flate.Reader); ok {
+		return rr
+	}
+	return bufio.NewReader(r)
+}
+
+func (z *Reader) readString() (string, error) {
+	var err error
+	needConv := false
+	for i := 0; ; i++ {
+		if i >= len(z.buf) {
+			return "", ErrHeader
+		}
+		z.buf[i], err = z.r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if z.buf[i] > 0x7f {
+			needConv = true
+		}
+		if z.buf[i] == 0 {
+			if needConv {
+				s := make([]rune, 0, i)
+				for _, v := range z.buf[:i] {
+					s = append(s, rune(v))
+				}
+				return string(s), nil
+			}
+			return string(z.buf[:i]), nil
+		}
+	}
+}
+
+func (z *Reader) read2() (uint32, error) {
+	_, err := io.ReadFull(z.r, z.buf[:2])
+	if err != nil {
+		return 0, err
+	}
+	return uint32(z.buf[0]) | uint32(z.buf[1])<<8, nil
+}
+
+func (z *Reader) readHeader() (err error) {
+	memberStart := z.cr.offset()
+	if br, ok := z.r.(*bufio.Reader); ok {
+		memberStart -= int64(br.Buffered())
+	}
+
+	if _, err = io.ReadFull(z.r, z.buf[:10]); err != nil {
+		return err
+	}
+	if z.buf[0] != gzipID1 || z.buf[1] != gzipID2 || z.buf[2] != gzipDeflate {
+		return ErrHeader
+	}
+	flg := z.buf[3]
+	z.ModTime = time.Unix(int64(le32(z.buf[4:8])), 0)
+	// z.buf[8] is xfl, ignored
+	z.OS = z.buf[9]
+
+	if flg&flagExtra != 0 {
+		n, err := z.read2()
+		if err != nil {
+			return noEOF(err)
+		}
+		z.Extra = make([]byte, n)
+		if _, err := io.ReadFull(z.r, z.Extra); err != nil {
+			return noEOF(err)
+		}
+		if z.bgzfBlockSize == 0 && z.uncompressedOffset == 0 {
+			if size, ok := bgzfBlockSize(z.Extra); ok {
+				z.bgzfBlockSize = size
+			}
+		}
+	}
+
+	var s string
+	if flg&flagName != 0 {
+		if s, err = z.readString(); err != nil {
+			return noEOF(err)
+		}
+		z.Name = s
+	}
+
+	if flg&flagComment != 0 {
+		if s, err = z.readString(); err != nil {
+			return noEOF(err)
+		}
+		z.Comment = s
+	}
+
+	if flg&flagHdrCrc != 0 {
+		if _, err := z.read2(); err != nil {
+			return noEOF(err)
+		}
+	}
+
+	z.digest = 0
+	deflateStart := z.cr.offset()
+	if br, ok := z.r.(*bufio.Reader); ok {
+		deflateStart -= int64(br.Buffered())
+	}
+	z.compressedOffset = deflateStart
+	z.recordMemberStart(memberStart, deflateStart)
+	if z.decompressor == nil {
+		z.decompressor = flate.NewReader(z.r)
+	} else {
+		z.decompressor.(flate.Resetter).Reset(z.r, nil)
+	}
+	return nil
+}
+
+// Read implements io.Reader, reading uncompressed bytes from its underlying Reader.
+func (z *Reader) Read(p []byte) (n int, err error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+
+	for n == 0 {
+		n, z.err = z.decompressor.Read(p)
+		z.digest = crc32.Update(z.digest, crc32.IEEETable, p[:n])
+		z.size += uint32(n)
+		z.uncompressedOffset += int64(n)
+		// compressedOffset is the number of source bytes flate has
+		// consumed, excluding whatever the bufio layer has prefetched.
+		// BuildIndex uses it for policy state, progress reports and
+		// checkpoint candidates; flate may already hold bits of the next
+		// block, so a candidate is only kept once verifyCheckpoint (see
+		// index.go) shows that decoding restarts correctly from it.
+		if ra, ok := z.r.(*bufio.Reader); ok {
+			z.compressedOffset = z.cr.offset() - int64(ra.Buffered())
+		} else {
+			z.compressedOffset = z.cr.offset()
+		}
+		if z.err != io.EOF {
+			// In the normal case we return here.
+			return n, z.err
+		}
+
+		// Finished file; check checksum and size.
+		if _, err := io.ReadFull(z.r, z.buf[:8]); err != nil {
+			z.err = noEOF(err)
+			return n, z.err
+		}
+		digest := le32(z.buf[:4])
+		size := le32(z.buf[4:8])
+		if digest != z.digest || size != z.size {
+			z.err = ErrChecksum
+			return n, z.err
+		}
+		z.digest, z.size = 0, 0
+		z.finalizeLastMember()
+
+		// File is ok; check if there is another.
+		if !z.multistream {
+			return n, io.EOF
+		}
+		z.err = nil // Remove io.EOF
+
+		if err = z.readHeader(); err != nil {
+			z.err = err
+			return n, z.err
+		}
+	}
+
+	return n, nil
+}
+
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func le32(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+// Close closes the Reader. It does not close the underlying io.Reader.
+func (z *Reader) Close() error { return z.decompressor.Close() }
+
+// countingReader wraps an io.Reader, tracking the total number of bytes
+// read from it so that Reader can approximate its position in the
+// compressed source for indexing purposes. base lets the count be
+// reported relative to the start of the original source, even when r
+// itself is a section starting partway through it (as after
+// SeekToMember).
+type countingReader struct {
+	r    io.Reader
+	base int64
+	n    int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// offset returns the absolute number of bytes consumed from the original
+// source so far.
+func (c *countingReader) offset() int64 {
+	return c.base + c.n
+}
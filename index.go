@@ -0,0 +1,300 @@
+package gzseek
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+)
+
+// windowSize is the amount of decompressed history (the "window") that
+// flate needs as a dictionary in order to resume decoding at an arbitrary
+// point in the stream. It matches the maximum back-reference distance
+// supported by DEFLATE (RFC 1951 section 3.2.5).
+const windowSize = 32 * 1024
+
+// Checkpoint is a decoder restart point captured while indexing a gzip
+// stream. Given a Checkpoint, a flate.Reader can be primed with Window as
+// its dictionary and Reset to read starting at CompressedOffset, producing
+// the same bytes the original stream would have produced from
+// UncompressedOffset onward.
+type Checkpoint struct {
+	// CompressedOffset is the byte offset into the compressed source at
+	// which decoding may resume.
+	CompressedOffset int64
+	// UncompressedOffset is the corresponding offset into the
+	// decompressed stream.
+	UncompressedOffset int64
+	// Window holds up to the trailing 32 KiB of decompressed output
+	// immediately preceding CompressedOffset. It is shorter only for the
+	// checkpoint nearest the start of the stream, and nil for checkpoints
+	// that fall at the start of a member, which need no dictionary.
+	Window []byte
+	// MemberIndex is the index into Reader.Members() of the member this
+	// checkpoint falls within.
+	MemberIndex int
+	// Bits is the number of bits of the byte at CompressedOffset-1 that
+	// belong to the previous deflate block, following zran.c's
+	// convention for non-byte-aligned restart points. This
+	// implementation only ever produces byte-aligned checkpoints, so
+	// Bits is always 0; it is preserved in the on-disk format (see
+	// index_io.go) for interoperability with indexes built by zran.c
+	// itself or a future bit-exact indexer.
+	Bits uint8
+}
+
+// Index is an ordered set of Checkpoints spanning a gzip stream, built by
+// Reader.BuildIndex. It is sufficient to Seek to, or ReadRangeParallel
+// from, any uncompressed offset without decompressing from the start of
+// the stream.
+type Index struct {
+	checkpoints []Checkpoint
+}
+
+// Checkpoints returns the Index's checkpoints in increasing order of
+// UncompressedOffset.
+func (idx *Index) Checkpoints() []Checkpoint {
+	return idx.checkpoints
+}
+
+// SetIndex attaches a previously-built Index (e.g. one loaded with
+// LoadIndex) to z, so that Seek and ReadRangeParallel can use it without
+// having called BuildIndex on z itself.
+func (z *Reader) SetIndex(idx *Index) {
+	z.index = idx
+}
+
+// checkpointFor returns the last checkpoint at or before the given
+// uncompressed offset, or false if off precedes the first checkpoint.
+func (idx *Index) checkpointFor(off int64) (Checkpoint, bool) {
+	var best Checkpoint
+	found := false
+	for _, c := range idx.checkpoints {
+		if c.UncompressedOffset > off {
+			break
+		}
+		best, found = c, true
+	}
+	return best, found
+}
+
+// checkpointVerifyLen is how many decompressed bytes a policy-driven
+// (non-member-start) checkpoint candidate must reproduce, by actually
+// restarting flate from it, before BuildIndex trusts it enough to add it
+// to the Index. compress/flate exposes no way to ask whether a given
+// byte offset is a deflate block boundary (unlike zlib's Z_BLOCK), so a
+// candidate offset produced by an ordinary Read call is only sometimes
+// one; this check is what keeps a bad guess out of the Index instead of
+// corrupting a later Seek.
+const checkpointVerifyLen = 256
+
+// pendingCheckpoint is a candidate Checkpoint awaiting enough
+// newly-decoded bytes to verify against before BuildIndex commits to it.
+type pendingCheckpoint struct {
+	cp     Checkpoint
+	verify []byte
+}
+
+// BuildIndex performs a dedicated forward pass over z, decompressing the
+// entire stream and consulting policy at each opportunity to checkpoint
+// (see IndexPolicy). It must be called before any other data has been
+// read from z (i.e. immediately after NewReader), since it consumes the
+// stream in the process of indexing it.
+//
+// progress, if non-nil, is called after each chunk of decompressed output
+// with the cumulative uncompressed and compressed byte counts processed
+// so far. ctx is checked between chunks, so a canceled ctx aborts the
+// indexing pass promptly.
+//
+// The returned Index is also stored on z so that subsequent calls to Seek
+// and ReadRangeParallel can use it.
+func (z *Reader) BuildIndex(ctx context.Context, policy IndexPolicy, progress func(uncompressed, compressed int64)) (*Index, error) {
+	idx := &Index{checkpoints: []Checkpoint{{
+		CompressedOffset:   z.compressedOffset,
+		UncompressedOffset: z.uncompressedOffset,
+		Window:             nil,
+	}}}
+
+	window := make([]byte, 0, windowSize)
+	lastUncompressed, lastCompressed := int64(0), int64(0)
+	prevMemberCount := len(z.members)
+	var pending []*pendingCheckpoint
+	// A small buffer gives policy-driven candidates more distinct
+	// Read-return offsets to try per span, which matters because only a
+	// small fraction of them land on an actual deflate block boundary
+	// (see checkpointVerifyLen); a larger buffer would starve short spans
+	// of candidates entirely.
+	buf := make([]byte, 4*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := z.Read(buf)
+		if n > 0 {
+			chunkStart := z.uncompressedOffset - int64(n)
+			chunk := buf[:n]
+			window = appendWindow(window, chunk)
+
+			isMemberStart := len(z.members) > prevMemberCount
+			prevMemberCount = len(z.members)
+			if isMemberStart {
+				// No more bytes from the previous member are coming;
+				// judge its pending candidates on what they've got.
+				idx.flushPending(z.src, pending)
+				pending = pending[:0]
+			} else if len(pending) > 0 {
+				fillPending(pending, chunkStart, chunk)
+				pending = idx.resolveReady(z.src, pending, &lastUncompressed, &lastCompressed)
+			}
+
+			state := CheckpointState{
+				UncompressedBytesSinceLast: z.uncompressedOffset - lastUncompressed,
+				CompressedBytesSinceLast:   z.compressedOffset - lastCompressed,
+				AtBlockBoundary:            true,
+				IsMemberStart:              isMemberStart,
+			}
+			if policy.ShouldCheckpoint(state) {
+				if isMemberStart {
+					// Folded in for free below, since a member start
+					// needs no verification.
+					lastUncompressed, lastCompressed = z.uncompressedOffset, z.compressedOffset
+				} else if z.readerAt != nil {
+					// Don't advance last* yet: whether this candidate
+					// pans out won't be known until resolveReady
+					// verifies it, and until then the policy should
+					// keep offering fresh candidates rather than
+					// waiting out a whole span on a single guess.
+					pending = append(pending, &pendingCheckpoint{cp: Checkpoint{
+						CompressedOffset:   z.compressedOffset,
+						UncompressedOffset: z.uncompressedOffset,
+						Window:             append([]byte(nil), window...),
+						MemberIndex:        len(z.members) - 1,
+					}})
+				}
+			}
+			if progress != nil {
+				progress(z.uncompressedOffset, z.compressedOffset)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	idx.flushPending(z.src, pending)
+
+	// Member starts are checkpoints too, and free ones at that: a new
+	// member's flate stream begins at DeflateOffset with no dictionary
+	// needed, so unlike a mid-member candidate it requires no
+	// verification. Folding them in lets Seek and ReadRangeParallel skip
+	// whole members using the preceding member's compressed length,
+	// rather than always resuming from the last policy-driven
+	// checkpoint, regardless of which policy was used.
+	for i, m := range z.members {
+		if i == 0 {
+			continue // already the index's first checkpoint
+		}
+		idx.checkpoints = append(idx.checkpoints, Checkpoint{
+			CompressedOffset:   m.DeflateOffset,
+			UncompressedOffset: m.UncompressedOffset,
+			Window:             nil,
+			MemberIndex:        i,
+		})
+	}
+	sort.Slice(idx.checkpoints, func(i, j int) bool {
+		return idx.checkpoints[i].UncompressedOffset < idx.checkpoints[j].UncompressedOffset
+	})
+
+	z.index = idx
+	return idx, nil
+}
+
+// appendWindow appends p to window, keeping only the trailing windowSize
+// bytes.
+func appendWindow(window, p []byte) []byte {
+	window = append(window, p...)
+	if len(window) > windowSize {
+		window = window[len(window)-windowSize:]
+	}
+	return window
+}
+
+// fillPending feeds each pending candidate's verification buffer with
+// whatever it still needs from the overlap of [chunkStart,
+// chunkStart+len(chunk)) with [cp.UncompressedOffset,
+// cp.UncompressedOffset+checkpointVerifyLen).
+func fillPending(pending []*pendingCheckpoint, chunkStart int64, chunk []byte) {
+	chunkEnd := chunkStart + int64(len(chunk))
+	for _, p := range pending {
+		needStart := p.cp.UncompressedOffset + int64(len(p.verify))
+		needEnd := p.cp.UncompressedOffset + checkpointVerifyLen
+		start, end := needStart, needEnd
+		if start < chunkStart {
+			start = chunkStart
+		}
+		if end > chunkEnd {
+			end = chunkEnd
+		}
+		if end > start {
+			p.verify = append(p.verify, chunk[start-chunkStart:end-chunkStart]...)
+		}
+	}
+}
+
+// resolveReady verifies and commits every pending candidate whose
+// verification buffer is full, dropping it from pending either way. On a
+// successful verification, *lastUncompressed and *lastCompressed are
+// advanced to the candidate's offsets, so that a span-based policy only
+// starts offering further candidates once another full span has passed
+// since the last checkpoint that actually stuck. Candidates still short
+// of checkpointVerifyLen are kept for later.
+func (idx *Index) resolveReady(src io.Reader, pending []*pendingCheckpoint, lastUncompressed, lastCompressed *int64) []*pendingCheckpoint {
+	kept := pending[:0]
+	for _, p := range pending {
+		if int64(len(p.verify)) < checkpointVerifyLen {
+			kept = append(kept, p)
+			continue
+		}
+		if verifyCheckpoint(src, p.cp, p.verify) {
+			idx.checkpoints = append(idx.checkpoints, p.cp)
+			*lastUncompressed, *lastCompressed = p.cp.UncompressedOffset, p.cp.CompressedOffset
+		}
+	}
+	return kept
+}
+
+// flushPending verifies and commits every remaining pending candidate
+// against whatever (possibly short of checkpointVerifyLen) verification
+// data it managed to collect, e.g. because its member or the stream
+// ended first.
+func (idx *Index) flushPending(src io.Reader, pending []*pendingCheckpoint) {
+	for _, p := range pending {
+		if verifyCheckpoint(src, p.cp, p.verify) {
+			idx.checkpoints = append(idx.checkpoints, p.cp)
+		}
+	}
+}
+
+// verifyCheckpoint reports whether restarting flate decoding at cp
+// actually reproduces want, the decompressed bytes already known to
+// follow cp.UncompressedOffset. This is the only reliable way to tell
+// whether cp.CompressedOffset happened to land on a deflate block
+// boundary, since compress/flate does not expose decoder bit position.
+func verifyCheckpoint(src io.Reader, cp Checkpoint, want []byte) bool {
+	if len(want) == 0 {
+		return true
+	}
+	dec, _, err := restoreAt(src, cp)
+	if err != nil {
+		return false
+	}
+	defer dec.Close()
+	got := make([]byte, len(want))
+	n, err := io.ReadFull(dec, got)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false
+	}
+	return n == len(want) && bytes.Equal(got, want)
+}
@@ -0,0 +1,231 @@
+package gzseek
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// On-disk index format, in the spirit of zlib's examples/zran.c: a fixed
+// header followed by one fixed-size record per checkpoint.
+//
+//	magic            [4]byte  "GZRX"
+//	version          uint8
+//	flags            uint8    bit 0: windows are raw-deflate compressed
+//	windowSize       uint32   le
+//	entryCount       uint32   le
+//	entries          []indexEntry
+//
+// Each indexEntry is:
+//
+//	bits               uint8
+//	memberIndex        uint32 le
+//	compressedOffset   uint64 le
+//	uncompressedOffset uint64 le
+//	windowLen          uint32 le   (compressed length if flags bit 0 is set)
+//	window             [windowLen]byte
+var indexMagic = [4]byte{'G', 'Z', 'R', 'X'}
+
+const indexVersion = 1
+
+const indexFlagWindowsCompressed = 1 << 0
+
+// ErrBadIndex is returned by LoadIndex when the input is not a
+// recognizable index file.
+var ErrBadIndex = errors.New("gzseek: not a gzseek index file")
+
+// WriteTo writes idx in gzseek's on-disk index format to w. Window data is
+// stored raw-deflate compressed, which typically shrinks the index
+// 3-4x relative to storing the 32 KiB windows uncompressed.
+func (idx *Index) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	if _, err := bw.Write(indexMagic[:]); err != nil {
+		return cw.n, err
+	}
+	if err := bw.WriteByte(indexVersion); err != nil {
+		return cw.n, err
+	}
+	if err := bw.WriteByte(indexFlagWindowsCompressed); err != nil {
+		return cw.n, err
+	}
+	if err := writeUint32(bw, uint32(windowSize)); err != nil {
+		return cw.n, err
+	}
+	if err := writeUint32(bw, uint32(len(idx.checkpoints))); err != nil {
+		return cw.n, err
+	}
+
+	var fbuf bytes.Buffer
+	for _, c := range idx.checkpoints {
+		if err := bw.WriteByte(c.Bits); err != nil {
+			return cw.n, err
+		}
+		if err := writeUint32(bw, uint32(c.MemberIndex)); err != nil {
+			return cw.n, err
+		}
+		if err := writeUint64(bw, uint64(c.CompressedOffset)); err != nil {
+			return cw.n, err
+		}
+		if err := writeUint64(bw, uint64(c.UncompressedOffset)); err != nil {
+			return cw.n, err
+		}
+
+		fbuf.Reset()
+		fw, err := flate.NewWriter(&fbuf, flate.BestSpeed)
+		if err != nil {
+			return cw.n, err
+		}
+		if _, err := fw.Write(c.Window); err != nil {
+			return cw.n, err
+		}
+		if err := fw.Close(); err != nil {
+			return cw.n, err
+		}
+
+		if err := writeUint32(bw, uint32(fbuf.Len())); err != nil {
+			return cw.n, err
+		}
+		if _, err := bw.Write(fbuf.Bytes()); err != nil {
+			return cw.n, err
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return cw.n, err
+	}
+	return cw.n, nil
+}
+
+// LoadIndex reads an index written by Index.WriteTo.
+func LoadIndex(r io.Reader) (*Index, error) {
+	br := bufio.NewReader(r)
+
+	var magic [4]byte
+	if _, err := io.ReadFull(br, magic[:]); err != nil {
+		return nil, err
+	}
+	if magic != indexMagic {
+		return nil, ErrBadIndex
+	}
+	version, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if version != indexVersion {
+		return nil, errors.New("gzseek: unsupported index version")
+	}
+	flags, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	compressed := flags&indexFlagWindowsCompressed != 0
+
+	wsize, err := readUint32(br)
+	if err != nil {
+		return nil, err
+	}
+	count, err := readUint32(br)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &Index{checkpoints: make([]Checkpoint, count)}
+	for i := range idx.checkpoints {
+		c := &idx.checkpoints[i]
+		c.Bits, err = br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		mi, err := readUint32(br)
+		if err != nil {
+			return nil, err
+		}
+		c.MemberIndex = int(mi)
+		co, err := readUint64(br)
+		if err != nil {
+			return nil, err
+		}
+		c.CompressedOffset = int64(co)
+		uo, err := readUint64(br)
+		if err != nil {
+			return nil, err
+		}
+		c.UncompressedOffset = int64(uo)
+
+		wlen, err := readUint32(br)
+		if err != nil {
+			return nil, err
+		}
+		raw := make([]byte, wlen)
+		if _, err := io.ReadFull(br, raw); err != nil {
+			return nil, err
+		}
+		if !compressed {
+			c.Window = raw
+			continue
+		}
+		fr := flate.NewReader(bytes.NewReader(raw))
+		window := make([]byte, 0, wsize)
+		buf := make([]byte, 4096)
+		for {
+			n, err := fr.Read(buf)
+			window = append(window, buf[:n]...)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		fr.Close()
+		c.Window = window
+	}
+
+	return idx, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func writeUint32(w io.Writer, v uint32) error {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	_, err := w.Write(b[:])
+	return err
+}
+
+func writeUint64(w io.Writer, v uint64) error {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	_, err := w.Write(b[:])
+	return err
+}
+
+func readUint32(r io.Reader) (uint32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b[:]), nil
+}
+
+func readUint64(r io.Reader) (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b[:]), nil
+}
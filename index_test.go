@@ -0,0 +1,93 @@
+package gzseek
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+)
+
+// readerAtBytes adapts a []byte to io.Reader + io.ReaderAt, as a gzip file
+// opened from disk would support.
+type readerAtBytes struct {
+	*bytes.Reader
+}
+
+func makeGzipFixture(t *testing.T, n int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := io.CopyN(w, newPseudoRandomReader(), int64(n)); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("closing fixture writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// newPseudoRandomReader returns a deterministic, essentially
+// incompressible, effectively unbounded byte stream so that makeGzipFixture
+// can produce a fixture of any requested size without running out of
+// source data. A fixed-seed PRNG is used rather than a repeating literal
+// pattern so that the resulting gzip stream has many genuine deflate block
+// boundaries spread across its length, instead of collapsing into a
+// handful of giant back-reference matches that a policy-driven index could
+// never find a checkpoint within.
+func newPseudoRandomReader() io.Reader {
+	return io.NopCloser(&pseudoRandomReader{state: 0x9e3779b97f4a7c15})
+}
+
+// pseudoRandomReader generates bytes from a fixed-seed xorshift64 PRNG.
+type pseudoRandomReader struct {
+	state uint64
+}
+
+func (r *pseudoRandomReader) Read(p []byte) (int, error) {
+	for i := range p {
+		r.state ^= r.state << 13
+		r.state ^= r.state >> 7
+		r.state ^= r.state << 17
+		p[i] = byte(r.state)
+	}
+	return len(p), nil
+}
+
+func TestBuildIndexAndSeek(t *testing.T) {
+	raw := makeGzipFixture(t, 4<<20)
+
+	z, err := NewReader(readerAtBytes{bytes.NewReader(raw)})
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	idx, err := z.BuildIndex(context.Background(), FixedUncompressedSpan(256*1024), nil)
+	if err != nil {
+		t.Fatalf("BuildIndex: %v", err)
+	}
+	if len(idx.Checkpoints()) < 2 {
+		t.Fatalf("expected multiple checkpoints, got %d", len(idx.Checkpoints()))
+	}
+
+	// Decompress the whole stream independently for comparison.
+	z2, err := NewReader(readerAtBytes{bytes.NewReader(raw)})
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	want, err := io.ReadAll(z2)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+
+	off := int64(len(want) / 2)
+	if _, err := z.Seek(off, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	got, err := io.ReadAll(z)
+	if err != nil {
+		t.Fatalf("ReadAll after Seek: %v", err)
+	}
+	if !bytes.Equal(got, want[off:]) {
+		t.Fatalf("Seek(%d) produced mismatched data (%d bytes vs want %d)", off, len(got), len(want)-int(off))
+	}
+}
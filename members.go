@@ -0,0 +1,101 @@
+package gzseek
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// MemberInfo describes one member of a (possibly multi-member, per RFC
+// 1952 section 2.2) gzip stream.
+type MemberInfo struct {
+	Name               string
+	Comment            string
+	ModTime            time.Time
+	CompressedOffset   int64
+	UncompressedOffset int64
+	// DeflateOffset is the compressed offset of the first byte of this
+	// member's deflate bitstream, i.e. CompressedOffset plus the size of
+	// the gzip header. Unlike CompressedOffset, it is a valid restart
+	// point for flate.NewReaderDict (see restoreAt in seek.go); resuming
+	// at CompressedOffset would feed header bytes to the flate decoder.
+	DeflateOffset int64
+	// UncompressedSize is -1 until the member has been fully read (or
+	// skipped past), since gzip does not record it in the header.
+	UncompressedSize int64
+}
+
+// recordMemberStart appends a new in-progress MemberInfo for the member
+// whose header begins at compressedOffset and whose deflate bitstream
+// begins at deflateOffset, finalizing the previous member's
+// UncompressedSize if there is one.
+func (z *Reader) recordMemberStart(compressedOffset, deflateOffset int64) {
+	z.finalizeLastMember()
+	z.members = append(z.members, MemberInfo{
+		Name:               z.Name,
+		Comment:            z.Comment,
+		ModTime:            z.ModTime,
+		CompressedOffset:   compressedOffset,
+		UncompressedOffset: z.uncompressedOffset,
+		DeflateOffset:      deflateOffset,
+		UncompressedSize:   -1,
+	})
+}
+
+// finalizeLastMember records the UncompressedSize of the most recently
+// started member now that its trailer has been read and validated.
+func (z *Reader) finalizeLastMember() {
+	if n := len(z.members); n > 0 {
+		last := &z.members[n-1]
+		last.UncompressedSize = z.uncompressedOffset - last.UncompressedOffset
+	}
+}
+
+// Members returns MemberInfo for every member encountered so far. Since
+// gzip headers are only parsed as the stream is read, a stream that
+// hasn't been fully read will be missing its later members; call
+// ScanMembers first to force a full pass.
+func (z *Reader) Members() []MemberInfo {
+	return z.members
+}
+
+// ScanMembers reads z to EOF (discarding output) so that Members reflects
+// every member in the stream. It is a no-op if the stream has already
+// been fully read.
+func (z *Reader) ScanMembers() error {
+	_, err := io.Copy(io.Discard, z)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// SeekToMember positions z to begin reading member i from its start,
+// without decoding any preceding member. i indexes into Members(), so the
+// stream must already have been scanned (directly or via BuildIndex / a
+// prior read) far enough to have discovered member i.
+func (z *Reader) SeekToMember(i int) error {
+	if i < 0 || i >= len(z.members) {
+		return errors.New("gzseek: member index out of range")
+	}
+	if z.readerAt == nil {
+		return ErrNotReaderAt
+	}
+	m := z.members[i]
+	sr := io.NewSectionReader(z.readerAt, m.CompressedOffset, 1<<63-1-m.CompressedOffset)
+
+	members, index := z.members, z.index
+	cr := &countingReader{r: sr, base: m.CompressedOffset}
+	*z = Reader{
+		cr:          cr,
+		r:           makeReader(cr),
+		multistream: true,
+		src:         z.src,
+		readerAt:    z.readerAt,
+		members:     members[:i],
+		index:       index,
+	}
+	z.compressedOffset = m.CompressedOffset
+	z.uncompressedOffset = m.UncompressedOffset
+	return z.readHeader()
+}
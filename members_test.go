@@ -0,0 +1,58 @@
+package gzseek
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+func makeMultiMemberFixture(t *testing.T, payloads []string) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	for _, p := range payloads {
+		w := gzip.NewWriter(&out)
+		if _, err := io.WriteString(w, p); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+	return out.Bytes()
+}
+
+func TestMembersAndSeekToMember(t *testing.T) {
+	payloads := []string{"first member\n", "second member, a bit longer\n", "third\n"}
+	raw := makeMultiMemberFixture(t, payloads)
+	src := readerAtBytes{bytes.NewReader(raw)}
+
+	z, err := NewReader(src)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	if err := z.ScanMembers(); err != nil {
+		t.Fatalf("ScanMembers: %v", err)
+	}
+
+	members := z.Members()
+	if len(members) != len(payloads) {
+		t.Fatalf("got %d members, want %d", len(members), len(payloads))
+	}
+	for i, m := range members {
+		if m.UncompressedSize != int64(len(payloads[i])) {
+			t.Errorf("member %d: got size %d, want %d", i, m.UncompressedSize, len(payloads[i]))
+		}
+	}
+
+	if err := z.SeekToMember(2); err != nil {
+		t.Fatalf("SeekToMember: %v", err)
+	}
+	got, err := io.ReadAll(z)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != payloads[2] {
+		t.Fatalf("SeekToMember(2) produced %q, want %q", got, payloads[2])
+	}
+}
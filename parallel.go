@@ -0,0 +1,210 @@
+package gzseek
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// DefaultMinChunkSize is the smallest sub-range ReadRangeParallel will hand
+// to its own goroutine. Requests smaller than nWorkers*DefaultMinChunkSize
+// are decompressed with fewer workers to avoid oversubscribing for small
+// reads.
+const DefaultMinChunkSize = 4 * windowSize
+
+// ReadRangeParallel decompresses the uncompressed range [off, off+len(dst))
+// into dst using up to nWorkers goroutines, each independently seeking the
+// underlying source (which must implement io.ReaderAt) to the nearest
+// preceding checkpoint and decoding forward to its assigned sub-range.
+//
+// An index must have been built with BuildIndex or attached with SetIndex
+// first; the index granularity bounds how
+// finely the range can be split, since each worker's sub-range must start
+// at a checkpoint and end at a checkpoint or at EOF.
+func (z *Reader) ReadRangeParallel(dst []byte, off int64, nWorkers int) (int, error) {
+	if z.index == nil {
+		return 0, ErrNoIndex
+	}
+	if _, ok := z.src.(io.ReaderAt); !ok {
+		return 0, ErrNotReaderAt
+	}
+	if nWorkers < 1 {
+		nWorkers = 1
+	}
+	want := int64(len(dst))
+	if want == 0 {
+		return 0, nil
+	}
+
+	chunks, err := planChunks(z.index, off, want, nWorkers, DefaultMinChunkSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(chunks))
+	for i, c := range chunks {
+		wg.Add(1)
+		go func(i int, c rangeChunk) {
+			defer wg.Done()
+			errs[i] = decodeChunk(z.src, dst, off, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := want
+	if last := chunks[len(chunks)-1]; last.end < off+want {
+		n = last.end - off
+	}
+	return int(n), nil
+}
+
+// rangeChunk is a single worker's share of the requested range, expressed
+// as uncompressed offsets. start is aligned to a checkpoint at or before
+// it; end is aligned to a checkpoint or to the end of the requested range.
+type rangeChunk struct {
+	checkpoint Checkpoint
+	start, end int64 // uncompressed offsets, absolute
+}
+
+// planChunks partitions [off, off+want) into contiguous rangeChunks, each
+// anchored at the nearest preceding index checkpoint, targeting nWorkers
+// pieces no smaller than minChunk. It returns an error if off precedes
+// every checkpoint in idx, since there is then no valid anchor to decode
+// forward from.
+func planChunks(idx *Index, off, want int64, nWorkers int, minChunk int64) ([]rangeChunk, error) {
+	end := off + want
+	cps := idx.checkpoints
+
+	// Checkpoints strictly inside (off, end) are the candidate split
+	// points; combined with off and end they define the chunk boundaries.
+	bounds := []int64{off}
+	for _, c := range cps {
+		if c.UncompressedOffset > off && c.UncompressedOffset < end {
+			bounds = append(bounds, c.UncompressedOffset)
+		}
+	}
+	bounds = append(bounds, end)
+
+	// Merge adjacent boundaries so that no chunk but the last is smaller
+	// than minChunk; end is always kept, so the last chunk may be short.
+	merged := bounds[:1]
+	for _, b := range bounds[1:] {
+		if b-merged[len(merged)-1] < minChunk && b != end {
+			continue
+		}
+		merged = append(merged, b)
+	}
+	if merged[len(merged)-1] != end {
+		merged[len(merged)-1] = end
+	}
+
+	// Cap the number of chunks at nWorkers by merging the smallest
+	// adjacent pairs until we fit.
+	for len(merged)-1 > nWorkers {
+		merged = mergeSmallestPair(merged)
+	}
+
+	chunks := make([]rangeChunk, 0, len(merged)-1)
+	for i := 0; i < len(merged)-1; i++ {
+		start, end := merged[i], merged[i+1]
+		cp, ok := idx.checkpointFor(start)
+		if !ok {
+			return nil, fmt.Errorf("gzseek: offset %d precedes the first checkpoint at %d", start, cps[0].UncompressedOffset)
+		}
+		chunks = append(chunks, rangeChunk{checkpoint: cp, start: start, end: end})
+	}
+	return chunks, nil
+}
+
+func mergeSmallestPair(bounds []int64) []int64 {
+	// The first and last bounds are the ends of the requested range and
+	// always stay; dropping interior bound i merges its two neighbours.
+	best := 1
+	bestSize := bounds[2] - bounds[0]
+	for i := 2; i < len(bounds)-1; i++ {
+		size := bounds[i+1] - bounds[i-1]
+		if size < bestSize {
+			best, bestSize = i, size
+		}
+	}
+	out := make([]int64, 0, len(bounds)-1)
+	out = append(out, bounds[:best]...)
+	out = append(out, bounds[best+1:]...)
+	return out
+}
+
+// decodeChunk restores flate state at c.checkpoint and decodes forward,
+// writing into dst (whose index 0 corresponds to uncompressed offset off)
+// until c.end is reached.
+func decodeChunk(src io.Reader, dst []byte, off int64, c rangeChunk) error {
+	dec, _, err := restoreAt(src, c.checkpoint)
+	if err != nil {
+		return err
+	}
+	defer dec.Close()
+
+	pos := c.checkpoint.UncompressedOffset
+	buf := make([]byte, 32*1024)
+	for pos < c.end {
+		n, err := dec.Read(buf)
+		if n > 0 {
+			chunkStart, chunkEnd := pos, pos+int64(n)
+			data := buf[:n]
+			if chunkEnd > c.end {
+				// Stop exactly at c.end; what follows belongs to the
+				// next chunk's worker.
+				data = data[:c.end-chunkStart]
+				chunkEnd = c.end
+			}
+			if chunkStart < c.start {
+				if chunkEnd <= c.start {
+					pos = chunkEnd
+					if err != nil && err != io.EOF {
+						return err
+					}
+					continue
+				}
+				buf2 := data[c.start-chunkStart:]
+				chunkStart = c.start
+				copyClamped(dst, off, chunkStart, chunkEnd, buf2)
+			} else {
+				copyClamped(dst, off, chunkStart, chunkEnd, data)
+			}
+			pos = chunkEnd
+		}
+		if err == io.EOF {
+			if pos < c.end {
+				return errors.New("gzseek: unexpected EOF before end of chunk")
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyClamped copies src (uncompressed bytes covering [chunkStart,
+// chunkEnd)) into dst (covering [off, off+len(dst))), clamping to
+// whichever range is shorter.
+func copyClamped(dst []byte, off, chunkStart, chunkEnd int64, src []byte) {
+	dstStart := chunkStart - off
+	dstEnd := chunkEnd - off
+	if dstStart < 0 {
+		src = src[-dstStart:]
+		dstStart = 0
+	}
+	if dstEnd > int64(len(dst)) {
+		src = src[:int64(len(src))-(dstEnd-int64(len(dst)))]
+	}
+	copy(dst[dstStart:], src)
+}
@@ -0,0 +1,42 @@
+package gzseek
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+)
+
+func TestReadRangeParallel(t *testing.T) {
+	raw := makeGzipFixture(t, 4<<20)
+
+	z, err := NewReader(readerAtBytes{bytes.NewReader(raw)})
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	if _, err := z.BuildIndex(context.Background(), FixedUncompressedSpan(256*1024), nil); err != nil {
+		t.Fatalf("BuildIndex: %v", err)
+	}
+
+	z2, err := NewReader(readerAtBytes{bytes.NewReader(raw)})
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	want, err := io.ReadAll(z2)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+
+	off := int64(len(want) / 4)
+	dst := make([]byte, len(want)/2)
+	n, err := z.ReadRangeParallel(dst, off, 4)
+	if err != nil {
+		t.Fatalf("ReadRangeParallel: %v", err)
+	}
+	if n != len(dst) {
+		t.Fatalf("got %d bytes, want %d", n, len(dst))
+	}
+	if !bytes.Equal(dst, want[off:off+int64(len(dst))]) {
+		t.Fatalf("ReadRangeParallel produced mismatched data")
+	}
+}
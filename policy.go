@@ -0,0 +1,66 @@
+package gzseek
+
+// CheckpointState describes the decoder's state at a point where an
+// IndexPolicy may choose to place a Checkpoint.
+type CheckpointState struct {
+	// UncompressedBytesSinceLast is the number of uncompressed bytes
+	// produced since the last checkpoint (or the start of the stream).
+	UncompressedBytesSinceLast int64
+	// CompressedBytesSinceLast is the number of compressed bytes consumed
+	// since the last checkpoint (or the start of the stream).
+	CompressedBytesSinceLast int64
+	// AtBlockBoundary is always true as set by BuildIndex, which cannot
+	// tell where deflate blocks end: it consults an IndexPolicy after
+	// each chunk of decompressed output, and that point is usually not a
+	// block boundary. A mid-member checkpoint placed there is kept only
+	// if restarting decoding from it reproduces the bytes that follow.
+	AtBlockBoundary bool
+	// IsMemberStart reports whether this boundary is also the start of a
+	// new gzip member, which needs no dictionary to resume from at all.
+	IsMemberStart bool
+}
+
+// IndexPolicy decides where Reader.BuildIndex places checkpoints. It is
+// consulted after each chunk of decompressed output, not only at deflate
+// block boundaries (see CheckpointState.AtBlockBoundary).
+type IndexPolicy interface {
+	ShouldCheckpoint(state CheckpointState) bool
+}
+
+// IndexPolicyFunc adapts a function to an IndexPolicy.
+type IndexPolicyFunc func(state CheckpointState) bool
+
+// ShouldCheckpoint calls f.
+func (f IndexPolicyFunc) ShouldCheckpoint(state CheckpointState) bool { return f(state) }
+
+// FixedUncompressedSpan returns an IndexPolicy that checkpoints roughly
+// every n uncompressed bytes.
+func FixedUncompressedSpan(n int64) IndexPolicy {
+	return IndexPolicyFunc(func(state CheckpointState) bool {
+		return state.UncompressedBytesSinceLast >= n
+	})
+}
+
+// FixedCompressedSpan returns an IndexPolicy that checkpoints roughly
+// every n compressed bytes.
+func FixedCompressedSpan(n int64) IndexPolicy {
+	return IndexPolicyFunc(func(state CheckpointState) bool {
+		return state.CompressedBytesSinceLast >= n
+	})
+}
+
+// EveryBlock returns an IndexPolicy that checkpoints at every deflate
+// block boundary, maximizing seek precision at the cost of a much larger
+// index.
+func EveryBlock() IndexPolicy {
+	return IndexPolicyFunc(func(CheckpointState) bool { return true })
+}
+
+// EveryMember returns an IndexPolicy that checkpoints only at the start
+// of each gzip member. This is essentially free to compute (member starts
+// are recorded as checkpoints regardless of policy; see BuildIndex) and
+// ideal for multi-member files produced by parallel gzip writers such as
+// pgzip, where member boundaries are already fine-grained.
+func EveryMember() IndexPolicy {
+	return IndexPolicyFunc(func(state CheckpointState) bool { return state.IsMemberStart })
+}
@@ -0,0 +1,48 @@
+package gzseek
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestIndexPolicies(t *testing.T) {
+	raw := makeGzipFixture(t, 4<<20)
+
+	z1, err := NewReader(readerAtBytes{bytes.NewReader(raw)})
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	fineIdx, err := z1.BuildIndex(context.Background(), FixedUncompressedSpan(64*1024), nil)
+	if err != nil {
+		t.Fatalf("BuildIndex (fine): %v", err)
+	}
+
+	z2, err := NewReader(readerAtBytes{bytes.NewReader(raw)})
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	coarseIdx, err := z2.BuildIndex(context.Background(), FixedUncompressedSpan(1<<20), nil)
+	if err != nil {
+		t.Fatalf("BuildIndex (coarse): %v", err)
+	}
+
+	if len(fineIdx.Checkpoints()) <= len(coarseIdx.Checkpoints()) {
+		t.Fatalf("expected a finer span to produce more checkpoints: got %d fine, %d coarse",
+			len(fineIdx.Checkpoints()), len(coarseIdx.Checkpoints()))
+	}
+
+	z3, err := NewReader(readerAtBytes{bytes.NewReader(raw)})
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	memberIdx, err := z3.BuildIndex(context.Background(), EveryMember(), nil)
+	if err != nil {
+		t.Fatalf("BuildIndex (EveryMember): %v", err)
+	}
+	// The fixture is a single member, so EveryMember should produce just
+	// the implicit start-of-stream checkpoint.
+	if got := len(memberIdx.Checkpoints()); got != 1 {
+		t.Fatalf("EveryMember on a single-member stream: got %d checkpoints, want 1", got)
+	}
+}
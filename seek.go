@@ -0,0 +1,79 @@
+package gzseek
+
+import (
+	"bufio"
+	"compress/flate"
+	"errors"
+	"io"
+)
+
+// ErrNoIndex is returned by Seek and ReadRangeParallel when no Index has
+// been built for the Reader yet.
+var ErrNoIndex = errors.New("gzseek: reader has no index; call BuildIndex first")
+
+// ErrNotReaderAt is returned when an operation requires the Reader's
+// source to implement io.ReaderAt (so that multiple restart points can be
+// read independently) and it does not.
+var ErrNotReaderAt = errors.New("gzseek: source does not implement io.ReaderAt")
+
+// Pos returns z's current position in the decompressed stream.
+func (z *Reader) Pos() int64 {
+	return z.uncompressedOffset
+}
+
+// Seek implements io.Seeker, repositioning z to read from the given
+// uncompressed offset. Only whence == io.SeekStart is supported.
+//
+// Seek requires an index, built with BuildIndex or attached with SetIndex.
+func (z *Reader) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekStart {
+		return 0, errors.New("gzseek: only io.SeekStart is supported")
+	}
+	if z.index == nil {
+		return 0, ErrNoIndex
+	}
+	cp, ok := z.index.checkpointFor(offset)
+	if !ok {
+		return 0, errors.New("gzseek: offset precedes start of stream")
+	}
+
+	dec, r, err := restoreAt(z.src, cp)
+	if err != nil {
+		return 0, err
+	}
+	z.decompressor = dec
+	z.r = r
+	z.err = nil
+	z.digest = 0
+	z.size = 0
+	z.uncompressedOffset = cp.UncompressedOffset
+	z.compressedOffset = cp.CompressedOffset
+
+	// Discard forward to the requested offset; the flate block containing
+	// it has already been reached, we just haven't decoded that far yet.
+	if skip := offset - cp.UncompressedOffset; skip > 0 {
+		if _, err := io.CopyN(io.Discard, readerFunc(z.Read), skip); err != nil {
+			return 0, err
+		}
+	}
+	return offset, nil
+}
+
+// restoreAt creates a flate decompressor primed to resume decoding at
+// cp.CompressedOffset with cp.Window as its dictionary, reading from src
+// starting at that byte offset. src must implement io.ReaderAt.
+func restoreAt(src io.Reader, cp Checkpoint) (io.ReadCloser, flate.Reader, error) {
+	ra, ok := src.(io.ReaderAt)
+	if !ok {
+		return nil, nil, ErrNotReaderAt
+	}
+	sr := io.NewSectionReader(ra, cp.CompressedOffset, 1<<63-1-cp.CompressedOffset)
+	fr := bufio.NewReader(sr)
+	dec := flate.NewReaderDict(fr, cp.Window)
+	return dec, fr, nil
+}
+
+// readerFunc adapts a Read method value to an io.Reader.
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
@@ -0,0 +1,176 @@
+// Package tarseek provides O(log n) random access to entries inside a
+// .tar.gz archive, built on top of gzseek's seekable gzip reader.
+//
+// Opening an archive walks the tar headers once, using the underlying
+// gzip seek index to skip each entry's body rather than decompressing it.
+// Subsequent Open calls for individual entries seek directly to the
+// entry's body.
+package tarseek
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	gzseek "github.com/timpalpant/gzran"
+)
+
+// tarBlockSize is the block size tar pads entry bodies to (see the POSIX
+// ustar format).
+const tarBlockSize = 512
+
+// Entry describes one file's location within the archive.
+type Entry struct {
+	Header tar.Header
+	// UncompressedOffset is the offset, in the decompressed tar stream,
+	// of the first byte of the entry's body.
+	UncompressedOffset int64
+	// Size is the entry's body length in bytes, equal to Header.Size.
+	Size int64
+}
+
+// Archive is a .tar.gz archive opened for random access.
+type Archive struct {
+	z       *gzseek.Reader
+	entries map[string]Entry
+	names   []string
+}
+
+// Open walks the tar headers in r, skipping entry bodies via r's gzip
+// index (built internally with the given span; see gzseek.Reader.BuildIndex)
+// rather than decompressing them, and returns an Archive from which
+// individual entries can be opened by name.
+func Open(r io.ReaderAt, span int64) (*Archive, error) {
+	z, err := gzseek.NewReader(&readerAtReader{ra: r})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := z.BuildIndex(context.Background(), gzseek.FixedUncompressedSpan(span), nil); err != nil {
+		return nil, err
+	}
+	if _, err := z.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	a := &Archive{z: z, entries: make(map[string]Entry)}
+	for {
+		tr := tar.NewReader(z)
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		bodyStart := z.Pos()
+		entry := Entry{Header: *hdr, UncompressedOffset: bodyStart, Size: hdr.Size}
+		a.entries[hdr.Name] = entry
+		a.names = append(a.names, hdr.Name)
+
+		bodyEnd := bodyStart + alignBlock(hdr.Size)
+		if _, err := z.Seek(bodyEnd, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func alignBlock(n int64) int64 {
+	if r := n % tarBlockSize; r != 0 {
+		n += tarBlockSize - r
+	}
+	return n
+}
+
+// Names returns the archive's entry names, in the order they appear in
+// the tar stream.
+func (a *Archive) Names() []string {
+	return a.names
+}
+
+// Stat returns the tar header for the named entry.
+func (a *Archive) Stat(name string) (tar.Header, error) {
+	e, ok := a.entries[name]
+	if !ok {
+		return tar.Header{}, fmt.Errorf("tarseek: no such entry %q", name)
+	}
+	return e.Header, nil
+}
+
+// Open returns an io.ReadSeeker positioned at the start of the named
+// entry's body. Reads are clamped to the entry's size and a seek outside
+// [0, size] returns an error; the returned reader refuses to cross into
+// the next header.
+func (a *Archive) Open(name string) (io.ReadSeeker, error) {
+	e, ok := a.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("tarseek: no such entry %q", name)
+	}
+	if _, err := a.z.Seek(e.UncompressedOffset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return &entryReader{z: a.z, entry: e}, nil
+}
+
+// entryReader clamps reads and seeks to a single tar entry's body.
+type entryReader struct {
+	z     *gzseek.Reader
+	entry Entry
+	pos   int64
+}
+
+func (r *entryReader) Read(p []byte) (int, error) {
+	remaining := r.entry.Size - r.pos
+	if remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := r.z.Read(p)
+	r.pos += int64(n)
+	return n, err
+}
+
+func (r *entryReader) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = r.pos + offset
+	case io.SeekEnd:
+		target = r.entry.Size + offset
+	default:
+		return 0, errors.New("tarseek: invalid whence")
+	}
+	if target < 0 || target > r.entry.Size {
+		return 0, errors.New("tarseek: seek out of range")
+	}
+	if _, err := r.z.Seek(r.entry.UncompressedOffset+target, io.SeekStart); err != nil {
+		return 0, err
+	}
+	r.pos = target
+	return target, nil
+}
+
+// readerAtReader adapts an io.ReaderAt to also provide sequential
+// io.Reader semantics starting at offset 0, which is what gzseek.NewReader
+// requires while still allowing restoreAt's io.ReaderAt checks to succeed.
+type readerAtReader struct {
+	ra  io.ReaderAt
+	pos int64
+}
+
+func (r *readerAtReader) Read(p []byte) (int, error) {
+	n, err := r.ra.ReadAt(p, r.pos)
+	r.pos += int64(n)
+	return n, err
+}
+
+func (r *readerAtReader) ReadAt(p []byte, off int64) (int, error) {
+	return r.ra.ReadAt(p, off)
+}
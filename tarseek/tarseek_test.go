@@ -0,0 +1,97 @@
+package tarseek
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+type fileContent struct {
+	name string
+	body string
+}
+
+func makeTarGzFixture(t *testing.T, files []fileContent) []byte {
+	t.Helper()
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.body))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("WriteHeader: %v", err)
+		}
+		if _, err := tw.Write([]byte(f.body)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar Close: %v", err)
+	}
+
+	var gzBuf bytes.Buffer
+	gw := gzip.NewWriter(&gzBuf)
+	if _, err := gw.Write(tarBuf.Bytes()); err != nil {
+		t.Fatalf("gzip Write: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("gzip Close: %v", err)
+	}
+	return gzBuf.Bytes()
+}
+
+func TestOpenAndReadEntries(t *testing.T) {
+	files := []fileContent{
+		{"a.txt", "contents of a\n"},
+		{"dir/b.txt", "contents of b, somewhat longer than a\n"},
+		{"c.bin", string(bytes.Repeat([]byte{0xab, 0xcd}, 1000))},
+	}
+	raw := makeTarGzFixture(t, files)
+
+	a, err := Open(bytes.NewReader(raw), 4096)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	names := a.Names()
+	if len(names) != len(files) {
+		t.Fatalf("got %d names, want %d", len(names), len(files))
+	}
+
+	for _, f := range files {
+		hdr, err := a.Stat(f.name)
+		if err != nil {
+			t.Fatalf("Stat(%q): %v", f.name, err)
+		}
+		if hdr.Size != int64(len(f.body)) {
+			t.Errorf("Stat(%q): got size %d, want %d", f.name, hdr.Size, len(f.body))
+		}
+
+		r, err := a.Open(f.name)
+		if err != nil {
+			t.Fatalf("Open(%q): %v", f.name, err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("ReadAll(%q): %v", f.name, err)
+		}
+		if string(got) != f.body {
+			t.Errorf("Open(%q) produced mismatched content", f.name)
+		}
+	}
+
+	// Opening entries out of order should still work, since each Open
+	// seeks independently.
+	r, err := a.Open(files[0].name)
+	if err != nil {
+		t.Fatalf("Open(%q) out of order: %v", files[0].name, err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != files[0].body {
+		t.Errorf("out-of-order Open(%q) produced mismatched content", files[0].name)
+	}
+}